@@ -0,0 +1,35 @@
+package docker
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+// Backend interface represents a container engine, implemented by
+// dockerBackend; newPodmanBackend and newSSHBackend return a dockerBackend
+// pointed at Podman's socket or at a remote host over SSH.
+type Backend interface {
+	ContainerCreate(ctx context.Context, args ContainerCreateArgs) error
+	ContainerStart(ctx context.Context, name string) error
+	ContainerStop(ctx context.Context, name string, timeout time.Duration) error
+	ContainerRemove(ctx context.Context, name string) error
+	ContainerExec(ctx context.Context, args ContainerExecArgs) error
+	ContainerExecResize(ctx context.Context, execID string, fd uintptr) error
+	ContainerNetwork(ctx context.Context, name string, wantConnected bool) error
+	ContainerMounts(ctx context.Context, name string) ([]mount.Mount, error)
+	ContainerList(ctx context.Context, prefix string) ([]string, error)
+
+	IsContainerCreated(ctx context.Context, name string) (bool, error)
+	IsContainerStarted(ctx context.Context, name string) (bool, error)
+	IsContainerStopped(ctx context.Context, name string) (bool, error)
+
+	ImagePull(ctx context.Context, ref string) error
+	ImageBuild(ctx context.Context, tag string, dockerfile string) error
+
+	ContainerCheckpoint(ctx context.Context, args ContainerCheckpointArgs) error
+	ContainerRestore(ctx context.Context, name string, checkpointID string, checkpointDir string) error
+
+	ContainerStats(ctx context.Context, name string) (<-chan Stats, error)
+}
@@ -0,0 +1,39 @@
+package docker
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+)
+
+// ContainerCheckpointArgs struct represents arguments
+// passed to ContainerCheckpoint().
+type ContainerCheckpointArgs struct {
+	Container     string
+	CheckpointID  string
+	CheckpointDir string
+}
+
+// ContainerCheckpoint snapshots a running container's process state (via
+// the Docker Engine's CRIU integration) to CheckpointDir, so it can later
+// be resumed with ContainerRestore instead of starting from scratch.
+func (b *dockerBackend) ContainerCheckpoint(ctx context.Context, args ContainerCheckpointArgs) error {
+	options := types.CheckpointCreateOptions{
+		CheckpointID:  args.CheckpointID,
+		CheckpointDir: args.CheckpointDir,
+		Exit:          false,
+	}
+
+	return b.cli.CheckpointCreate(ctx, args.Container, options)
+}
+
+// ContainerRestore starts name from the checkpoint previously written by
+// ContainerCheckpoint, skipping whatever setup produced that snapshot.
+func (b *dockerBackend) ContainerRestore(ctx context.Context, name string, checkpointID string, checkpointDir string) error {
+	options := types.ContainerStartOptions{
+		CheckpointID:  checkpointID,
+		CheckpointDir: checkpointDir,
+	}
+
+	return b.cli.ContainerStart(ctx, name, options)
+}
@@ -1,10 +1,12 @@
 package docker
 
 import (
-	"errors"
+	"context"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/docker/pkg/term"
 	"io"
 	"os"
@@ -16,8 +18,9 @@ import (
 
 const (
 	// ContainerStopTimeout constant represents how long Docker Engine
-	// will wait for container before stopping it
-	ContainerStopTimeout = time.Millisecond * 10
+	// will wait for container before stopping it, used as the default
+	// when callers don't need a tighter bound
+	ContainerStopTimeout = time.Second * 10
 
 	// ContainerStateRunning constants defines that container is running
 	ContainerStateRunning = "running"
@@ -46,13 +49,38 @@ const (
 	ContainerCacheDir = "/var/cache/apt"
 )
 
+// fakerootCaps is the minimum set of capabilities dpkg-buildpackage needs
+// to fake root (via fakeroot) while the container otherwise runs with
+// CapDrop: [ALL].
+var fakerootCaps = []string{"CHOWN", "DAC_OVERRIDE", "FOWNER", "SETUID", "SETGID"}
+
 // ContainerCreateArgs struct represents arguments
 // passed to ContainerCreate().
 type ContainerCreateArgs struct {
-	Mounts []mount.Mount
-	Image  string
-	Name   string
-	User   string
+	Mounts      []mount.Mount
+	Image       string
+	Name        string
+	User        string
+	Resources   Resources
+	UserNSMode  string
+	UsernsRemap string
+	SecurityOpt []string
+}
+
+// Resources struct represents resource limits applied to a created
+// container, mirroring container.HostConfig.Resources but only exposing
+// the knobs deber's CLI flags need.
+type Resources struct {
+	// CPUShares is the relative CPU weight versus other containers.
+	CPUShares int64
+	// Memory is the hard memory limit in bytes; 0 means unlimited.
+	Memory int64
+	// PidsLimit caps the number of processes/threads in the container;
+	// 0 means unlimited.
+	PidsLimit int64
+	// BlockIOWeight is the relative block IO weight (10-1000); 0 means
+	// unset, leave it to Docker's default.
+	BlockIOWeight uint16
 }
 
 // ContainerExecArgs struct represents arguments
@@ -67,10 +95,27 @@ type ContainerExecArgs struct {
 	Network     bool
 }
 
+// dockerBackend implements Backend by talking to a real Docker Engine (or
+// anything speaking its API, like Podman) through the Docker Go SDK.
+type dockerBackend struct {
+	cli *client.Client
+}
+
+// newDockerBackend connects to a Docker Engine and returns a Backend
+// driving it.
+func newDockerBackend(opts ...client.Opt) (*dockerBackend, error) {
+	c, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dockerBackend{cli: c}, nil
+}
+
 // IsContainerCreated function checks if container is created
 // or simply just exists.
-func IsContainerCreated(name string) (bool, error) {
-	list, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+func (b *dockerBackend) IsContainerCreated(ctx context.Context, name string) (bool, error) {
+	list, err := b.cli.ContainerList(ctx, types.ContainerListOptions{All: true})
 	if err != nil {
 		return false, err
 	}
@@ -88,8 +133,8 @@ func IsContainerCreated(name string) (bool, error) {
 
 // IsContainerStarted function checks
 // if container's state == ContainerStateRunning.
-func IsContainerStarted(name string) (bool, error) {
-	list, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+func (b *dockerBackend) IsContainerStarted(ctx context.Context, name string) (bool, error) {
+	list, err := b.cli.ContainerList(ctx, types.ContainerListOptions{All: true})
 	if err != nil {
 		return false, err
 	}
@@ -109,8 +154,8 @@ func IsContainerStarted(name string) (bool, error) {
 
 // IsContainerStopped function checks
 // if container's state != ContainerStateRunning.
-func IsContainerStopped(name string) (bool, error) {
-	list, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+func (b *dockerBackend) IsContainerStopped(ctx context.Context, name string) (bool, error) {
+	list, err := b.cli.ContainerList(ctx, types.ContainerListOptions{All: true})
 	if err != nil {
 		return false, err
 	}
@@ -130,17 +175,46 @@ func IsContainerStopped(name string) (bool, error) {
 
 // ContainerCreate function creates container.
 //
-// It's up to the caller to make to-be-mounted directories on host.
-func ContainerCreate(args ContainerCreateArgs) error {
+// It's up to the caller to make to-be-mounted directories on host; on a
+// rootless daemon they're chowned to the remapped root so the container
+// can actually write into them.
+func (b *dockerBackend) ContainerCreate(ctx context.Context, args ContainerCreateArgs) error {
+	for _, m := range args.Mounts {
+		if m.Type == mount.TypeBind {
+			err := TranslateMountOwner(m.Source, args.UsernsRemap)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	hostConfig := &container.HostConfig{
 		Mounts: args.Mounts,
+		Resources: container.Resources{
+			CPUShares:   args.Resources.CPUShares,
+			Memory:      args.Resources.Memory,
+			PidsLimit:   args.Resources.PidsLimit,
+			BlkioWeight: args.Resources.BlockIOWeight,
+		},
+		UsernsMode:  container.UsernsMode(args.UserNSMode),
+		SecurityOpt: args.SecurityOpt,
+	}
+
+	// Dropping to fakerootCaps only makes sense where fakeroot is standing
+	// in for real root, i.e. a rootless/usernsremap build; gating it on
+	// anything else would silently remove capabilities (NET_RAW, SYS_ADMIN,
+	// ...) that an ordinary non-rootless dpkg-buildpackage may still need.
+	if IsRootless() || args.UserNSMode != "" {
+		hostConfig.CapDrop = []string{"ALL"}
+		hostConfig.CapAdd = fakerootCaps
 	}
+
 	config := &container.Config{
 		Image: args.Image,
 		User:  args.User,
 	}
 
-	_, err := cli.ContainerCreate(ctx, config, hostConfig, nil, args.Name)
+	_, err := b.cli.ContainerCreate(ctx, config, hostConfig, nil, args.Name)
 	if err != nil {
 		return err
 	}
@@ -149,30 +223,29 @@ func ContainerCreate(args ContainerCreateArgs) error {
 }
 
 // ContainerStart function starts container, just that.
-func ContainerStart(name string) error {
+func (b *dockerBackend) ContainerStart(ctx context.Context, name string) error {
 	options := types.ContainerStartOptions{}
 
-	return cli.ContainerStart(ctx, name, options)
+	return b.cli.ContainerStart(ctx, name, options)
 }
 
 // ContainerStop function stops container, just that.
 //
-// It utilizes ContainerStopTimeout constant.
-func ContainerStop(name string) error {
-	timeout := ContainerStopTimeout
-
-	return cli.ContainerStop(ctx, name, &timeout)
+// timeout bounds how long Docker Engine waits for the container to exit
+// on its own before sending it SIGKILL.
+func (b *dockerBackend) ContainerStop(ctx context.Context, name string, timeout time.Duration) error {
+	return b.cli.ContainerStop(ctx, name, &timeout)
 }
 
 // ContainerRemove function removes container, just that.
-func ContainerRemove(name string) error {
+func (b *dockerBackend) ContainerRemove(ctx context.Context, name string) error {
 	options := types.ContainerRemoveOptions{}
 
-	return cli.ContainerRemove(ctx, name, options)
+	return b.cli.ContainerRemove(ctx, name, options)
 }
 
-func ContainerMounts(name string) ([]mount.Mount, error) {
-	inspect, err := cli.ContainerInspect(ctx, name)
+func (b *dockerBackend) ContainerMounts(ctx context.Context, name string) ([]mount.Mount, error) {
+	inspect, err := b.cli.ContainerInspect(ctx, name)
 	if err != nil {
 		return nil, err
 	}
@@ -201,17 +274,20 @@ func ContainerMounts(name string) ([]mount.Mount, error) {
 // Command can be executed interactively.
 //
 // Command can be empty, in that case just bash is executed.
-func ContainerExec(args ContainerExecArgs) error {
+func (b *dockerBackend) ContainerExec(ctx context.Context, args ContainerExecArgs) error {
+	// Tty is only set for interactive execs: a TTY merges stdout/stderr
+	// into a single stream, which would stop ExecError.Stderr below from
+	// being stderr-only.
 	config := types.ExecConfig{
 		Cmd:          []string{"bash"},
 		WorkingDir:   args.WorkDir,
 		AttachStdin:  args.Interactive,
 		AttachStdout: true,
 		AttachStderr: true,
-		Tty:          true,
+		Tty:          args.Interactive,
 	}
 	check := types.ExecStartCheck{
-		Tty:    true,
+		Tty:    args.Interactive,
 		Detach: false,
 	}
 
@@ -227,17 +303,21 @@ func ContainerExec(args ContainerExecArgs) error {
 		config.Cmd = append(config.Cmd, "-c", args.Cmd)
 	}
 
-	err := ContainerNetwork(args.Name, args.Network)
+	err := b.ContainerNetwork(ctx, args.Name, args.Network)
 	if err != nil {
 		return err
 	}
 
-	response, err := cli.ContainerExecCreate(ctx, args.Name, config)
+	response, err := b.cli.ContainerExecCreate(ctx, args.Name, config)
 	if err != nil {
+		if client.IsErrNotFound(err) {
+			return ErrContainerNotFound
+		}
+
 		return err
 	}
 
-	hijack, err := cli.ContainerExecAttach(ctx, response.ID, check)
+	hijack, err := b.cli.ContainerExecAttach(ctx, response.ID, check)
 	if err != nil {
 		return err
 	}
@@ -252,45 +332,73 @@ func ContainerExec(args ContainerExecArgs) error {
 			}
 			defer term.RestoreTerminal(fd, oldState)
 
-			err = ContainerExecResize(response.ID, fd)
+			err = b.ContainerExecResize(ctx, response.ID, fd)
 			if err != nil {
 				return err
 			}
 
-			go resizeIfChanged(response.ID, fd)
+			go b.resizeIfChanged(ctx, response.ID, fd)
 			go io.Copy(hijack.Conn, os.Stdin)
 		}
 	}
 
-	io.Copy(os.Stdout, hijack.Conn)
+	go func() {
+		<-ctx.Done()
+		hijack.Close()
+
+		// ctx is already done, so give the container its own bounded
+		// context to shut down in instead of stopping it with no
+		// deadline at all.
+		stopCtx, cancel := WithTimeout(context.Background(), ContainerStopTimeout)
+		defer cancel()
+
+		b.ContainerStop(stopCtx, args.Name, ContainerStopTimeout)
+	}()
+
+	stderr := newCappedBuffer(execErrorStderrLimit)
+
+	if args.Interactive {
+		io.Copy(io.MultiWriter(os.Stdout, stderr), hijack.Conn)
+	} else {
+		stdcopy.StdCopy(os.Stdout, stderr, hijack.Conn)
+	}
+
 	hijack.Close()
 
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
 	if !args.Interactive {
-		inspect, err := cli.ContainerExecInspect(ctx, response.ID)
+		inspect, err := b.cli.ContainerExecInspect(ctx, response.ID)
 		if err != nil {
 			return err
 		}
 
 		if inspect.ExitCode != 0 {
-			return errors.New("command exited with non-zero status")
+			return newExecError(args.Cmd, inspect.ExitCode, stderr.Bytes())
 		}
 	}
 
 	return nil
 }
 
-func resizeIfChanged(execID string, fd uintptr) {
+func (b *dockerBackend) resizeIfChanged(ctx context.Context, execID string, fd uintptr) {
 	channel := make(chan os.Signal)
 	signal.Notify(channel, syscall.SIGWINCH)
 
 	for {
-		<-channel
-		ContainerExecResize(execID, fd)
+		select {
+		case <-ctx.Done():
+			return
+		case <-channel:
+			b.ContainerExecResize(ctx, execID, fd)
+		}
 	}
 }
 
 // ContainerExecResize function resizes TTY for exec process.
-func ContainerExecResize(execID string, fd uintptr) error {
+func (b *dockerBackend) ContainerExecResize(ctx context.Context, execID string, fd uintptr) error {
 	winSize, err := term.GetWinsize(fd)
 	if err != nil {
 		return err
@@ -301,7 +409,7 @@ func ContainerExecResize(execID string, fd uintptr) error {
 		Width:  uint(winSize.Width),
 	}
 
-	err = cli.ContainerExecResize(ctx, execID, options)
+	err = b.cli.ContainerExecResize(ctx, execID, options)
 	if err != nil {
 		return err
 	}
@@ -311,11 +419,11 @@ func ContainerExecResize(execID string, fd uintptr) error {
 
 // ContainerNetwork checks if container is connected to network
 // and then connects it or disconnects per caller request.
-func ContainerNetwork(name string, wantConnected bool) error {
+func (b *dockerBackend) ContainerNetwork(ctx context.Context, name string, wantConnected bool) error {
 	network := "bridge"
 	gotConnected := false
 
-	inspect, err := cli.ContainerInspect(ctx, name)
+	inspect, err := b.cli.ContainerInspect(ctx, name)
 	if err != nil {
 		return err
 	}
@@ -327,24 +435,24 @@ func ContainerNetwork(name string, wantConnected bool) error {
 	}
 
 	if wantConnected && !gotConnected {
-		return cli.NetworkConnect(ctx, network, name, nil)
+		return b.cli.NetworkConnect(ctx, network, name, nil)
 	}
 
 	if !wantConnected && gotConnected {
-		return cli.NetworkDisconnect(ctx, network, name, false)
+		return b.cli.NetworkDisconnect(ctx, network, name, false)
 	}
 
 	return nil
 }
 
 // ContainerList returns a list of containers that match passed criteria.
-func ContainerList(prefix string) ([]string, error) {
+func (b *dockerBackend) ContainerList(ctx context.Context, prefix string) ([]string, error) {
 	containers := make([]string, 0)
 	options := types.ContainerListOptions{
 		All: true,
 	}
 
-	list, err := cli.ContainerList(ctx, options)
+	list, err := b.cli.ContainerList(ctx, options)
 	if err != nil {
 		return nil, err
 	}
@@ -3,27 +3,156 @@ package docker
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
 )
 
 const (
 	// APIVersion constant is the minimum supported version of Docker Engine API
 	APIVersion = "1.30"
-)
 
-var (
-	cli *client.Client
-	ctx = context.Background()
+	// PodmanSocket constant is where Podman's Docker-compatible REST API
+	// listens by default when started with `podman system service`
+	PodmanSocket = "unix:///run/podman/podman.sock"
 )
 
-// New function creates fresh Docker struct and connects to Docker Engine.
-func New() error {
-	c, err := client.NewClientWithOpts(client.WithVersion(APIVersion))
+// Docker struct wraps the Backend chosen on the command line.
+type Docker struct {
+	backend Backend
+}
+
+// New function creates fresh Docker struct and connects to the container
+// engine selected by engine ("", "docker", "podman" or "ssh://user@host").
+func New(engine string) (*Docker, error) {
+	backend, err := newBackend(engine)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	cli = c
+	return &Docker{backend: backend}, nil
+}
+
+func newBackend(engine string) (Backend, error) {
+	switch {
+	case engine == "" || engine == "docker":
+		return newDockerBackend(client.WithVersion(APIVersion))
+	case engine == "podman":
+		return newPodmanBackend()
+	case strings.HasPrefix(engine, "ssh://"):
+		return newSSHBackend(engine)
+	default:
+		return nil, fmt.Errorf("unknown engine %q", engine)
+	}
+}
+
+// WithTimeout wraps ctx with a deadline of d, for callers that want a
+// single step (rather than the whole run) bounded in time.
+func WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, d)
+}
+
+// ContainerCreate function creates container.
+//
+// It's up to the caller to make to-be-mounted directories on host.
+func (d *Docker) ContainerCreate(ctx context.Context, args ContainerCreateArgs) error {
+	return d.backend.ContainerCreate(ctx, args)
+}
+
+// ContainerStart function starts container, just that.
+func (d *Docker) ContainerStart(ctx context.Context, name string) error {
+	return d.backend.ContainerStart(ctx, name)
+}
+
+// ContainerStop function stops container, just that.
+//
+// timeout bounds how long Docker Engine waits for the container to exit
+// on its own before killing it; pass ContainerStopTimeout for the
+// package's ten-second default.
+func (d *Docker) ContainerStop(ctx context.Context, name string, timeout time.Duration) error {
+	return d.backend.ContainerStop(ctx, name, timeout)
+}
+
+// ContainerRemove function removes container, just that.
+func (d *Docker) ContainerRemove(ctx context.Context, name string) error {
+	return d.backend.ContainerRemove(ctx, name)
+}
+
+// ContainerExec function executes a command in running container.
+func (d *Docker) ContainerExec(ctx context.Context, args ContainerExecArgs) error {
+	return d.backend.ContainerExec(ctx, args)
+}
+
+// ContainerExecResize function resizes TTY for exec process.
+func (d *Docker) ContainerExecResize(ctx context.Context, execID string, fd uintptr) error {
+	return d.backend.ContainerExecResize(ctx, execID, fd)
+}
+
+// ContainerEnableNetwork function connects container to network.
+func (d *Docker) ContainerEnableNetwork(ctx context.Context, name string) error {
+	return d.backend.ContainerNetwork(ctx, name, true)
+}
+
+// ContainerDisableNetwork function disconnects container from network.
+func (d *Docker) ContainerDisableNetwork(ctx context.Context, name string) error {
+	return d.backend.ContainerNetwork(ctx, name, false)
+}
+
+// ContainerMounts returns mounts of container.
+func (d *Docker) ContainerMounts(ctx context.Context, name string) ([]mount.Mount, error) {
+	return d.backend.ContainerMounts(ctx, name)
+}
+
+// ContainerList returns a list of containers that match passed criteria.
+func (d *Docker) ContainerList(ctx context.Context, prefix string) ([]string, error) {
+	return d.backend.ContainerList(ctx, prefix)
+}
+
+// IsContainerCreated function checks if container is created
+// or simply just exists.
+func (d *Docker) IsContainerCreated(ctx context.Context, name string) (bool, error) {
+	return d.backend.IsContainerCreated(ctx, name)
+}
+
+// IsContainerStarted function checks
+// if container's state == ContainerStateRunning.
+func (d *Docker) IsContainerStarted(ctx context.Context, name string) (bool, error) {
+	return d.backend.IsContainerStarted(ctx, name)
+}
+
+// IsContainerStopped function checks
+// if container's state != ContainerStateRunning.
+func (d *Docker) IsContainerStopped(ctx context.Context, name string) (bool, error) {
+	return d.backend.IsContainerStopped(ctx, name)
+}
+
+// ImagePull pulls image from registry.
+func (d *Docker) ImagePull(ctx context.Context, ref string) error {
+	return d.backend.ImagePull(ctx, ref)
+}
+
+// ImageBuild builds image out of dockerfile and tags it.
+func (d *Docker) ImageBuild(ctx context.Context, tag string, dockerfile string) error {
+	return d.backend.ImageBuild(ctx, tag, dockerfile)
+}
+
+// ContainerCheckpoint snapshots a running container's process state to
+// args.CheckpointDir so it can later be resumed with ContainerRestore.
+func (d *Docker) ContainerCheckpoint(ctx context.Context, args ContainerCheckpointArgs) error {
+	return d.backend.ContainerCheckpoint(ctx, args)
+}
+
+// ContainerRestore starts name from a checkpoint previously written by
+// ContainerCheckpoint.
+func (d *Docker) ContainerRestore(ctx context.Context, name string, checkpointID string, checkpointDir string) error {
+	return d.backend.ContainerRestore(ctx, name, checkpointID, checkpointDir)
+}
 
-	return nil
+// ContainerStats streams periodic resource-usage samples for name until
+// ctx is cancelled or the container stops.
+func (d *Docker) ContainerStats(ctx context.Context, name string) (<-chan Stats, error) {
+	return d.backend.ContainerStats(ctx, name)
 }
@@ -0,0 +1,105 @@
+package docker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// execErrorStderrLimit bounds how much of a failed command's output
+// ExecError keeps around.
+const execErrorStderrLimit = 32 * 1024 // 32 KB
+
+// Sentinel errors usable with errors.Is. ExecError unwraps to
+// ErrCommandNotFound, ErrCommandNotInvokable or ErrContainerKilled;
+// ErrContainerNotFound is returned by ContainerExec on its own.
+var (
+	// ErrContainerNotFound means ContainerExec targeted a container that
+	// doesn't exist (anymore).
+	ErrContainerNotFound = errors.New("container not found")
+	// ErrCommandNotFound corresponds to exit code 127.
+	ErrCommandNotFound = errors.New("command not found")
+	// ErrCommandNotInvokable corresponds to exit code 126.
+	ErrCommandNotInvokable = errors.New("could not invoke command")
+	// ErrContainerKilled means the executed command was terminated by a
+	// signal.
+	ErrContainerKilled = errors.New("container was killed")
+)
+
+// ExecError is returned by ContainerExec when the executed command fails.
+type ExecError struct {
+	// ExitCode is the process's exit status.
+	ExitCode int
+	// Signal is set when the command was terminated by a signal instead
+	// of exiting normally.
+	Signal string
+	// Stderr holds the last execErrorStderrLimit bytes written to the
+	// command's stderr, demultiplexed from its attached connection.
+	Stderr []byte
+	// Cmd is the command line that was executed.
+	Cmd string
+}
+
+func (e *ExecError) Error() string {
+	if e.Signal != "" {
+		return fmt.Sprintf("command %q killed by signal %s", e.Cmd, e.Signal)
+	}
+
+	return fmt.Sprintf("command %q exited with code %d", e.Cmd, e.ExitCode)
+}
+
+// Unwrap lets callers use errors.Is(err, docker.ErrCommandNotFound) against
+// an *ExecError.
+func (e *ExecError) Unwrap() error {
+	switch {
+	case e.Signal != "":
+		return ErrContainerKilled
+	case e.ExitCode == 127:
+		return ErrCommandNotFound
+	case e.ExitCode == 126:
+		return ErrCommandNotInvokable
+	default:
+		return nil
+	}
+}
+
+// newExecError builds an ExecError from a raw exit code, deriving Signal
+// when the code follows the 128+signal convention shells use.
+func newExecError(cmd string, exitCode int, stderr []byte) *ExecError {
+	execErr := &ExecError{
+		ExitCode: exitCode,
+		Stderr:   stderr,
+		Cmd:      cmd,
+	}
+
+	if exitCode > 128 {
+		execErr.Signal = syscall.Signal(exitCode - 128).String()
+	}
+
+	return execErr
+}
+
+// cappedBuffer keeps only the last limit bytes written to it.
+type cappedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func newCappedBuffer(limit int) *cappedBuffer {
+	return &cappedBuffer{limit: limit}
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	c.buf.Write(p)
+
+	if drop := c.buf.Len() - c.limit; drop > 0 {
+		c.buf.Next(drop)
+	}
+
+	return len(p), nil
+}
+
+func (c *cappedBuffer) Bytes() []byte {
+	return c.buf.Bytes()
+}
@@ -0,0 +1,50 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/archive"
+)
+
+// ImagePull pulls ref from a registry, discarding the progress stream.
+func (b *dockerBackend) ImagePull(ctx context.Context, ref string) error {
+	reader, err := b.cli.ImagePull(ctx, ref, types.ImagePullOptions{})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	_, err = io.Copy(ioutil.Discard, reader)
+
+	return err
+}
+
+// ImageBuild builds the directory containing dockerfile into an image and
+// tags it as tag.
+func (b *dockerBackend) ImageBuild(ctx context.Context, tag string, dockerfile string) error {
+	buildContext, err := archive.TarWithOptions(filepath.Dir(dockerfile), &archive.TarOptions{})
+	if err != nil {
+		return err
+	}
+	defer buildContext.Close()
+
+	options := types.ImageBuildOptions{
+		Tags:       []string{tag},
+		Dockerfile: filepath.Base(dockerfile),
+	}
+
+	response, err := b.cli.ImageBuild(ctx, buildContext, options)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	_, err = io.Copy(os.Stdout, response.Body)
+
+	return err
+}
@@ -0,0 +1,14 @@
+package docker
+
+import (
+	"github.com/docker/docker/client"
+)
+
+// newPodmanBackend connects to a running `podman system service` over its
+// Docker-compatible REST socket.
+func newPodmanBackend() (Backend, error) {
+	return newDockerBackend(
+		client.WithHost(PodmanSocket),
+		client.WithVersion(APIVersion),
+	)
+}
@@ -0,0 +1,90 @@
+package docker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// rootlessSocketPrefix is the default DOCKER_HOST for a per-user,
+// rootless Docker daemon.
+var rootlessSocketPrefix = "unix:///run/user/" + strconv.Itoa(os.Getuid()) + "/docker.sock"
+
+// IsRootless reports whether DOCKER_HOST points at a rootless daemon.
+func IsRootless() bool {
+	return strings.HasPrefix(os.Getenv("DOCKER_HOST"), rootlessSocketPrefix)
+}
+
+// TranslateMountOwner chowns a to-be-bind-mounted directory to the
+// remapped root UID/GID, so a rootless container can write into it.
+// remap is ContainerCreateArgs.UsernsRemap; when it carries no explicit
+// range, the invoking user's /etc/subuid and /etc/subgid entries are used
+// instead. It's a no-op when the daemon isn't rootless.
+func TranslateMountOwner(path string, remap string) error {
+	if !IsRootless() {
+		return nil
+	}
+
+	uid, gid, err := subordinateRootID(remap)
+	if err != nil {
+		return err
+	}
+
+	return os.Chown(path, uid, gid)
+}
+
+// subordinateRootID resolves the remapped root UID/GID, preferring an
+// explicit remap spec over /etc/subuid.
+func subordinateRootID(remap string) (int, int, error) {
+	if uid, gid, ok := parseUsernsRemap(remap); ok {
+		return uid, gid, nil
+	}
+
+	uid, err := firstSubordinateID("/etc/subuid")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	gid, err := firstSubordinateID("/etc/subgid")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return uid, gid, nil
+}
+
+// parseUsernsRemap extracts the UID/GID from a "name:uid:count" remap
+// spec. ok is false when remap carries no explicit range.
+func parseUsernsRemap(remap string) (uid int, gid int, ok bool) {
+	fields := strings.Split(remap, ":")
+	if len(fields) < 2 {
+		return 0, 0, false
+	}
+
+	uid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return uid, uid, true
+}
+
+func firstSubordinateID(path string) (int, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	user := os.Getenv("USER")
+
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) == 3 && fields[0] == user {
+			return strconv.Atoi(fields[1])
+		}
+	}
+
+	return 0, fmt.Errorf("no subordinate id range for %q in %s", user, path)
+}
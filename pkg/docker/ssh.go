@@ -0,0 +1,30 @@
+package docker
+
+import (
+	"net/http"
+
+	"github.com/docker/cli/cli/connhelper"
+	"github.com/docker/docker/client"
+)
+
+// newSSHBackend dials a remote Docker Engine over SSH (uri looks like
+// "ssh://user@host").
+func newSSHBackend(uri string) (Backend, error) {
+	helper, err := connhelper.GetConnectionHelper(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext: helper.Dialer,
+		},
+	}
+
+	return newDockerBackend(
+		client.WithHTTPClient(httpClient),
+		client.WithHost(helper.Host),
+		client.WithDialContext(helper.Dialer),
+		client.WithVersion(APIVersion),
+	)
+}
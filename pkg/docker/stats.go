@@ -0,0 +1,89 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/docker/docker/api/types"
+)
+
+// Stats is one sample off a container's stats stream, trimmed down to the
+// numbers the package step's build-stats.json monitor cares about.
+type Stats struct {
+	// MemoryUsage is the container's current resident memory, in bytes.
+	MemoryUsage uint64
+	// CPUPercent is the container's CPU usage over the sampling window,
+	// as a percentage of one core (so it can exceed 100 on multiple
+	// cores).
+	CPUPercent float64
+	// BlockWrite is the cumulative bytes written to block devices.
+	BlockWrite uint64
+}
+
+// ContainerStats streams periodic resource-usage samples for name until
+// ctx is cancelled or the container stops, closing the returned channel
+// either way.
+func (b *dockerBackend) ContainerStats(ctx context.Context, name string) (<-chan Stats, error) {
+	response, err := b.cli.ContainerStats(ctx, name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	channel := make(chan Stats)
+
+	go func() {
+		defer close(channel)
+		defer response.Body.Close()
+
+		decoder := json.NewDecoder(response.Body)
+
+		var previous types.StatsJSON
+
+		for {
+			var raw types.StatsJSON
+
+			err := decoder.Decode(&raw)
+			if err != nil {
+				return
+			}
+
+			stats := Stats{
+				MemoryUsage: raw.MemoryStats.Usage,
+				CPUPercent:  cpuPercent(previous, raw),
+				BlockWrite:  blockWrite(raw),
+			}
+			previous = raw
+
+			select {
+			case channel <- stats:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return channel, nil
+}
+
+func cpuPercent(previous, current types.StatsJSON) float64 {
+	cpuDelta := float64(current.CPUStats.CPUUsage.TotalUsage - previous.CPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(current.CPUStats.SystemUsage - previous.CPUStats.SystemUsage)
+
+	if systemDelta <= 0 || cpuDelta <= 0 {
+		return 0
+	}
+
+	return (cpuDelta / systemDelta) * float64(len(current.CPUStats.CPUUsage.PercpuUsage)) * 100
+}
+
+func blockWrite(stats types.StatsJSON) uint64 {
+	var written uint64
+
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		if entry.Op == "Write" {
+			written += entry.Value
+		}
+	}
+
+	return written
+}
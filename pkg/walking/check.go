@@ -1,12 +1,14 @@
 package walking
 
 import (
+	"context"
+	"os"
+
 	"github.com/dawidd6/deber/pkg/debian"
 	"github.com/dawidd6/deber/pkg/docker"
 	"github.com/dawidd6/deber/pkg/log"
 	"github.com/dawidd6/deber/pkg/naming"
 	"github.com/dawidd6/deber/pkg/stepping"
-	"os"
 )
 
 // StepCheck defines check step
@@ -22,7 +24,7 @@ var StepCheck = &stepping.Step{
 
 // Check function evaluates if package has been already built and
 // is in archive, if it is, then it exits with 0 code
-func Check(deb *debian.Debian, dock *docker.Docker, name *naming.Naming) error {
+func Check(ctx context.Context, deb *debian.Debian, dock *docker.Docker, name *naming.Naming) error {
 	log.Info("Checking archive")
 
 	info, _ := os.Stat(name.ArchivePackageDir)
@@ -0,0 +1,138 @@
+package walking
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/dawidd6/deber/pkg/debian"
+	"github.com/dawidd6/deber/pkg/docker"
+	"github.com/dawidd6/deber/pkg/log"
+	"github.com/dawidd6/deber/pkg/naming"
+	"github.com/dawidd6/deber/pkg/stepping"
+)
+
+// checkpointID names the single checkpoint deber keeps per container; only
+// one checkpoint is ever relevant at a time, so there's no need to juggle
+// several.
+const checkpointID = "deber"
+
+// controlHashFile is where, next to the checkpoint itself, the hash of the
+// debian/control that produced it is stored.
+const controlHashFile = "control.sha256"
+
+// StepCheckpoint defines checkpoint step
+var StepCheckpoint = &stepping.Step{
+	Name: "checkpoint",
+	Run:  Checkpoint,
+	Description: []string{
+		"Snapshots the container right after build-dependencies are installed.",
+		"On the next run, if debian/control hasn't changed, the snapshot is",
+		"restored instead of reinstalling build-dependencies from scratch.",
+		"There is no --no-checkpoint flag; excluding this step stops new",
+		"snapshots, but an existing one is still restored.",
+	},
+}
+
+// Checkpoint function snapshots the running container to
+// name.CheckpointDir and records the debian/control hash it was taken
+// against, so a later run can tell whether build-dependencies are still
+// current enough to restore from it.
+func Checkpoint(ctx context.Context, deb *debian.Debian, dock *docker.Docker, name *naming.Naming) error {
+	log.Info("Checkpointing container")
+
+	hash, err := controlHash(name)
+	if err != nil {
+		return log.FailE(err)
+	}
+
+	err = os.MkdirAll(name.CheckpointDir, 0755)
+	if err != nil {
+		return log.FailE(err)
+	}
+
+	args := docker.ContainerCheckpointArgs{
+		Container:     name.Container,
+		CheckpointID:  checkpointID,
+		CheckpointDir: name.CheckpointDir,
+	}
+	err = dock.ContainerCheckpoint(ctx, args)
+	if err != nil {
+		return log.FailE(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(name.CheckpointDir, controlHashFile), []byte(hash), 0644)
+	if err != nil {
+		return log.FailE(err)
+	}
+
+	return log.DoneE()
+}
+
+// StepRestore defines restore step
+var StepRestore = &stepping.Step{
+	Name: "restore",
+	Run:  Restore,
+	Description: []string{
+		"Starts the container from the checkpoint left by the checkpoint",
+		"step, skipping a fresh build-dependency install, as long as",
+		"debian/control still matches the one it was checkpointed against.",
+		"Falls back to a plain container start otherwise.",
+	},
+}
+
+// Restore function starts the container from its checkpoint when
+// CheckpointValid reports the checkpoint is still current for
+// debian/control; otherwise it starts the container normally and leaves
+// later steps to install build-dependencies from scratch.
+func Restore(ctx context.Context, deb *debian.Debian, dock *docker.Docker, name *naming.Naming) error {
+	log.Info("Restoring container")
+
+	if !CheckpointValid(name) {
+		err := dock.ContainerStart(ctx, name.Container)
+		if err != nil {
+			return log.FailE(err)
+		}
+
+		return log.SkipE()
+	}
+
+	err := dock.ContainerRestore(ctx, name.Container, checkpointID, name.CheckpointDir)
+	if err != nil {
+		return log.FailE(err)
+	}
+
+	return log.DoneE()
+}
+
+// CheckpointValid reports whether the checkpoint in name.CheckpointDir
+// was taken against the same debian/control that's about to be built, so
+// Restore knows it can restore instead of reinstalling
+// build-dependencies.
+func CheckpointValid(name *naming.Naming) bool {
+	stored, err := ioutil.ReadFile(filepath.Join(name.CheckpointDir, controlHashFile))
+	if err != nil {
+		return false
+	}
+
+	hash, err := controlHash(name)
+	if err != nil {
+		return false
+	}
+
+	return string(stored) == hash
+}
+
+func controlHash(name *naming.Naming) (string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(name.SourceDir, "debian", "control"))
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,61 @@
+package walking
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/dawidd6/deber/pkg/docker"
+	"github.com/dawidd6/deber/pkg/naming"
+)
+
+// statsEnabled follows the same env-var convention as dpkgFlags.
+//
+// Out of scope: there is no cmd/deber in this tree to host a --stats (or
+// --memory/--cpus/--pids-limit) flag, so DEBER_STATS is the only knob
+// until a real CLI package lands.
+var statsEnabled = os.Getenv("DEBER_STATS") != ""
+
+// buildStats is what gets written to build-stats.json.
+type buildStats struct {
+	PeakMemoryBytes uint64  `json:"peak_memory_bytes"`
+	PeakCPUPercent  float64 `json:"peak_cpu_percent"`
+	DiskWriteBytes  uint64  `json:"disk_write_bytes"`
+}
+
+// monitorUntilDone streams the container's resource usage until ctx is
+// cancelled or the container stops, then writes the peaks it observed to
+// build-stats.json. It only makes sense running concurrently with
+// dpkg-buildpackage, so Package runs it itself and waits for it to finish
+// rather than exposing it as its own sequential step.
+func monitorUntilDone(ctx context.Context, dock *docker.Docker, name *naming.Naming) error {
+	stream, err := dock.ContainerStats(ctx, name.Container)
+	if err != nil {
+		return err
+	}
+
+	var peak buildStats
+
+	for sample := range stream {
+		if sample.MemoryUsage > peak.PeakMemoryBytes {
+			peak.PeakMemoryBytes = sample.MemoryUsage
+		}
+		if sample.CPUPercent > peak.PeakCPUPercent {
+			peak.PeakCPUPercent = sample.CPUPercent
+		}
+		if sample.BlockWrite > peak.DiskWriteBytes {
+			peak.DiskWriteBytes = sample.BlockWrite
+		}
+	}
+
+	content, err := json.MarshalIndent(peak, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	file := fmt.Sprintf("%s/%s", name.ArchiveDir, "build-stats.json")
+
+	return ioutil.WriteFile(file, content, 0644)
+}
@@ -1,13 +1,15 @@
 package walking
 
 import (
+	"context"
 	"fmt"
+	"os"
+
 	"github.com/dawidd6/deber/pkg/debian"
 	"github.com/dawidd6/deber/pkg/docker"
 	"github.com/dawidd6/deber/pkg/log"
 	"github.com/dawidd6/deber/pkg/naming"
 	"github.com/dawidd6/deber/pkg/stepping"
-	"os"
 )
 
 var dpkgFlags = os.Getenv("DEBER_DPKG_BUILDPACKAGE_FLAGS")
@@ -26,7 +28,7 @@ var StepPackage = &stepping.Step{
 // Package function first disables network in container,
 // then executes "dpkg-buildpackage" and at the end,
 // enables network back
-func Package(deb *debian.Debian, dock *docker.Docker, name *naming.Naming) error {
+func Package(ctx context.Context, deb *debian.Debian, dock *docker.Docker, name *naming.Naming) error {
 	log.Info("Packaging software")
 
 	// TODO duplicated code, see Update()
@@ -39,19 +41,37 @@ func Package(deb *debian.Debian, dock *docker.Docker, name *naming.Naming) error
 		}
 	}
 
-	err := dock.ContainerDisableNetwork(name.Container)
+	err := dock.ContainerDisableNetwork(ctx, name.Container)
 	if err != nil {
 		return log.FailE(err)
 	}
-	defer dock.ContainerEnableNetwork(name.Container)
+	defer dock.ContainerEnableNetwork(ctx, name.Container)
 
 	log.Drop()
 
+	// See statsEnabled in monitor.go: gated on DEBER_STATS, not a CLI flag.
+	if statsEnabled {
+		monitorCtx, stopMonitor := context.WithCancel(ctx)
+		monitorDone := make(chan error, 1)
+
+		go func() {
+			monitorDone <- monitorUntilDone(monitorCtx, dock, name)
+		}()
+
+		defer func() {
+			stopMonitor()
+
+			if err := <-monitorDone; err != nil {
+				log.Info(fmt.Sprintf("Failed writing build stats: %s", err))
+			}
+		}()
+	}
+
 	args := docker.ContainerExecArgs{
 		Name: name.Container,
 		Cmd:  "dpkg-buildpackage" + " " + dpkgFlags,
 	}
-	err = dock.ContainerExec(args)
+	err = dock.ContainerExec(ctx, args)
 	if err != nil {
 		return log.FailE(err)
 	}
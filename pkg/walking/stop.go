@@ -1,6 +1,10 @@
 package walking
 
 import (
+	"context"
+	"os"
+	"time"
+
 	"github.com/dawidd6/deber/pkg/debian"
 	"github.com/dawidd6/deber/pkg/docker"
 	"github.com/dawidd6/deber/pkg/log"
@@ -8,21 +12,39 @@ import (
 	"github.com/dawidd6/deber/pkg/stepping"
 )
 
+// stopTimeout is docker.ContainerStopTimeout unless the caller overrides it
+// through DEBER_CONTAINER_STOP_TIMEOUT (same env-var convention as dpkgFlags
+// in package.go, until --timeout is wired up on the CLI).
+var stopTimeout = parseStopTimeout(os.Getenv("DEBER_CONTAINER_STOP_TIMEOUT"))
+
+func parseStopTimeout(value string) time.Duration {
+	if value == "" {
+		return docker.ContainerStopTimeout
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return docker.ContainerStopTimeout
+	}
+
+	return d
+}
+
 // StepStop defines stop step
 var StepStop = &stepping.Step{
 	Name: "stop",
 	Run:  Stop,
 	Description: []string{
 		"Stops container.",
-		"With " + docker.ContainerStopTimeout.String() + " timeout.",
+		"With " + stopTimeout.String() + " timeout.",
 	},
 }
 
 // Stop function commands Docker Engine to stop container
-func Stop(deb *debian.Debian, dock *docker.Docker, name *naming.Naming) error {
+func Stop(ctx context.Context, deb *debian.Debian, dock *docker.Docker, name *naming.Naming) error {
 	log.Info("Stopping container")
 
-	isContainerStopped, err := dock.IsContainerStopped(name.Container)
+	isContainerStopped, err := dock.IsContainerStopped(ctx, name.Container)
 	if err != nil {
 		return log.FailE(err)
 	}
@@ -30,7 +52,7 @@ func Stop(deb *debian.Debian, dock *docker.Docker, name *naming.Naming) error {
 		return log.SkipE()
 	}
 
-	err = dock.ContainerStop(name.Container)
+	err = dock.ContainerStop(ctx, name.Container, stopTimeout)
 	if err != nil {
 		return log.FailE(err)
 	}